@@ -0,0 +1,115 @@
+/*
+ * Flow CLI
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package project
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/onflow/flow-cli/cmd"
+	"github.com/onflow/flow-cli/flow/lib"
+	"github.com/onflow/flow-cli/flow/services"
+	"github.com/onflow/flow-cli/pkg/flowkit/resolvers"
+	"github.com/psiemens/sconfig"
+	"github.com/spf13/cobra"
+)
+
+const bundleCacheDir = ".flow/bundles"
+
+type flagsBundleInstall struct{}
+
+type cmdBundleInstall struct {
+	cmd   *cobra.Command
+	flags flagsBundleInstall
+}
+
+// NewBundleInstallCmd creates new bundle install command
+func NewBundleInstallCmd() cmd.Command {
+	return &cmdBundleInstall{
+		cmd: &cobra.Command{
+			Use:   "bundle install <url>",
+			Short: "Download a .cbundle into the local bundle cache",
+			Args:  cobra.ExactArgs(1),
+		},
+	}
+}
+
+// Run bundle install command
+func (i *cmdBundleInstall) Run(
+	cmd *cobra.Command,
+	args []string,
+	project *lib.Project,
+	services *services.Services,
+) (cmd.Result, error) {
+	url := args[0]
+
+	path, err := installBundle(url, bundleCacheDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to install bundle: %w", err)
+	}
+
+	return &BundlePackResult{path: path}, nil
+}
+
+// installBundle downloads the bundle at url and unpacks it to check that it is a
+// readable bundle with a valid manifest before placing it in cacheDir, keyed by the
+// manifest's own name and version so the BundleRegistry can find it later. File
+// checksums are not checked here; BundleLoader verifies them when a file is loaded.
+func installBundle(url string, cacheDir string) (string, error) {
+	tmp, err := ioutil.TempFile("", "*.cbundle")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	code, err := resolvers.FetchBundle(url)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := tmp.Write(code); err != nil {
+		return "", err
+	}
+	tmp.Close()
+
+	bundle, err := resolvers.UnpackBundle(tmp.Name())
+	if err != nil {
+		return "", err
+	}
+
+	destDir := filepath.Join(cacheDir, bundle.Manifest.Name)
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return "", err
+	}
+
+	dest := filepath.Join(destDir, bundle.Manifest.Version+".cbundle")
+	return dest, ioutil.WriteFile(dest, code, 0644)
+}
+
+// GetFlags for bundle install
+func (i *cmdBundleInstall) GetFlags() *sconfig.Config {
+	return sconfig.New(&i.flags)
+}
+
+// GetCmd get command
+func (i *cmdBundleInstall) GetCmd() *cobra.Command {
+	return i.cmd
+}
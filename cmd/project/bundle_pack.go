@@ -0,0 +1,106 @@
+/*
+ * Flow CLI
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package project
+
+import (
+	"fmt"
+
+	"github.com/onflow/flow-cli/cmd"
+	"github.com/onflow/flow-cli/flow/lib"
+	"github.com/onflow/flow-cli/flow/services"
+	"github.com/onflow/flow-cli/pkg/flowkit/resolvers"
+	"github.com/psiemens/sconfig"
+	"github.com/spf13/cobra"
+)
+
+type flagsBundlePack struct {
+	Out string `flag:"out" default:"" info:"output path for the .cbundle, defaults to <name>-<version>.cbundle"`
+}
+
+type cmdBundlePack struct {
+	cmd   *cobra.Command
+	flags flagsBundlePack
+}
+
+// NewBundlePackCmd creates new bundle pack command
+func NewBundlePackCmd() cmd.Command {
+	return &cmdBundlePack{
+		cmd: &cobra.Command{
+			Use:   "bundle pack <dir>",
+			Short: "Pack a directory of Cadence contracts into a .cbundle",
+			Args:  cobra.ExactArgs(1),
+		},
+	}
+}
+
+// Run bundle pack command
+func (p *cmdBundlePack) Run(
+	cmd *cobra.Command,
+	args []string,
+	project *lib.Project,
+	services *services.Services,
+) (cmd.Result, error) {
+	sourceDir := args[0]
+
+	out := p.flags.Out
+	if out == "" {
+		manifest, err := resolvers.ReadManifest(sourceDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bundle manifest: %w", err)
+		}
+
+		out = fmt.Sprintf("%s-%s.cbundle", manifest.Name, manifest.Version)
+	}
+
+	if err := resolvers.PackBundle(sourceDir, out); err != nil {
+		return nil, fmt.Errorf("failed to pack bundle: %w", err)
+	}
+
+	return &BundlePackResult{path: out}, nil
+}
+
+// GetFlags for bundle pack
+func (p *cmdBundlePack) GetFlags() *sconfig.Config {
+	return sconfig.New(&p.flags)
+}
+
+// GetCmd get command
+func (p *cmdBundlePack) GetCmd() *cobra.Command {
+	return p.cmd
+}
+
+// BundlePackResult result structure
+type BundlePackResult struct {
+	path string
+}
+
+// JSON convert result to JSON
+func (r *BundlePackResult) JSON() interface{} {
+	return map[string]string{"path": r.path}
+}
+
+// String convert result to string
+func (r *BundlePackResult) String() string {
+	return fmt.Sprintf("Bundle packed: %s", r.path)
+}
+
+// Oneliner show result as one liner grep friendly
+func (r *BundlePackResult) Oneliner() string {
+	return r.path
+}
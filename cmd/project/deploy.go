@@ -19,17 +19,26 @@
 package project
 
 import (
+	"fmt"
+	"strings"
+	"sync"
+
 	"github.com/onflow/flow-cli/cmd"
 	"github.com/onflow/flow-cli/flow/lib"
 	"github.com/onflow/flow-cli/flow/lib/contracts"
 	"github.com/onflow/flow-cli/flow/services"
+	"github.com/onflow/flow-cli/pkg/flowkit"
+	"github.com/onflow/flow-cli/pkg/flowkit/resolvers"
 	"github.com/psiemens/sconfig"
 	"github.com/spf13/cobra"
 )
 
 type flagsDeploy struct {
-	Network string `flag:"network" default:"emulator" info:"network configuration to use"`
-	Update  bool   `flag:"update" default:"false" info:"use update flag to update existing contracts"`
+	Network     string `flag:"network" default:"emulator" info:"network configuration to use"`
+	Update      bool   `flag:"update" default:"false" info:"use update flag to update existing contracts"`
+	MaxParallel int    `flag:"max-parallel" default:"1" info:"number of contracts to deploy concurrently within a dependency level"`
+	DryRun      bool   `flag:"dry-run" default:"false" info:"show the diff against what's deployed on chain without sending any transactions"`
+	Watch       bool   `flag:"watch" default:"false" info:"watch contract files and redeploy the affected subgraph on change"`
 }
 
 type cmdDeploy struct {
@@ -54,7 +63,20 @@ func (s *cmdDeploy) Run(
 	project *lib.Project,
 	services *services.Services,
 ) (cmd.Result, error) {
-	c, err := services.Project.Deploy(s.flags.Network, s.flags.Update)
+	if s.flags.DryRun {
+		diffs, err := diffDeployment(project, services, s.flags.Network)
+		return &DeployResult{diffs: diffs, project: project}, err
+	}
+
+	c, err := deployLayered(project, services, s.flags.Network, s.flags.Update, s.flags.MaxParallel)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.flags.Watch {
+		err = watchAndRedeploy(services, project, s.flags.Network, s.flags.Update)
+	}
+
 	return &DeployResult{contracts: c, project: project}, err
 }
 
@@ -68,20 +90,126 @@ func (s *cmdDeploy) GetCmd() *cobra.Command {
 	return s.cmd
 }
 
+// newProjectLoader builds the registry of location loaders (bundle, GitHub, IPFS,
+// HTTPS, file) shared by deploy, diff and watch.
+func newProjectLoader() *resolvers.LoaderRegistry {
+	return resolvers.NewLoaderRegistry(resolvers.NewBundleRegistry(bundleCacheDir, nil))
+}
+
+// projectLayers loads the contracts configured for network and groups them into Kahn
+// dependency layers - contracts within a layer share no dependency and can be deployed
+// concurrently.
+func projectLayers(project *lib.Project, network string) ([][]*flowkit.Contract, error) {
+	projectContracts, err := project.ContractsByNetwork(network)
+	if err != nil {
+		return nil, err
+	}
+
+	deployment, err := resolvers.NewDeployment(projectContracts, newProjectLoader())
+	if err != nil {
+		return nil, err
+	}
+
+	return deployment.Layers()
+}
+
+// deployLayered deploys every contract configured for network, one dependency layer at
+// a time, dispatching up to maxParallel deploys concurrently within each layer and
+// waiting for the whole layer to complete before starting the next.
+func deployLayered(
+	project *lib.Project,
+	svc *services.Services,
+	network string,
+	update bool,
+	maxParallel int,
+) ([]*contracts.Contract, error) {
+	layers, err := projectLayers(project, network)
+	if err != nil {
+		return nil, err
+	}
+
+	if maxParallel < 1 {
+		maxParallel = 1
+	}
+
+	deployed := make([]*contracts.Contract, 0)
+
+	for _, layer := range layers {
+		result, err := deployLayer(svc, layer, update, maxParallel)
+		deployed = append(deployed, result...)
+		if err != nil {
+			return deployed, err
+		}
+	}
+
+	return deployed, nil
+}
+
+// deployLayer deploys every contract in a single dependency layer concurrently, with at
+// most maxParallel deploys in flight at once, and waits for all of them to finish or
+// fail before returning - contracts within a layer share no dependency, so deploying
+// them out of order relative to each other is safe.
+func deployLayer(
+	svc *services.Services,
+	layer []*flowkit.Contract,
+	update bool,
+	maxParallel int,
+) ([]*contracts.Contract, error) {
+	sem := make(chan struct{}, maxParallel)
+	results := make([]*contracts.Contract, len(layer))
+	errs := make([]error, len(layer))
+
+	var wg sync.WaitGroup
+	for i, contract := range layer {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(i int, contract *flowkit.Contract) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			results[i], errs[i] = svc.Accounts.AddContract(contract.Target, contract.Name, contract.Location, update)
+		}(i, contract)
+	}
+	wg.Wait()
+
+	deployed := make([]*contracts.Contract, 0, len(layer))
+	var failed []string
+	for i, err := range errs {
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %s", layer[i].Name, err))
+			continue
+		}
+
+		deployed = append(deployed, results[i])
+	}
+
+	if len(failed) > 0 {
+		return deployed, fmt.Errorf("failed to deploy %d contract(s): %s", len(failed), strings.Join(failed, "; "))
+	}
+
+	return deployed, nil
+}
+
 // DeployResult result structure
 type DeployResult struct {
 	contracts []*contracts.Contract
+	diffs     []*ContractDiff
 	project   *lib.Project
 }
 
 // JSON convert result to JSON
 func (r *DeployResult) JSON() interface{} {
-	result := make(map[string]string, 0)
+	result := make(map[string]interface{}, 0)
 
 	for _, contract := range r.contracts {
 		result[contract.Name()] = contract.Target().String()
 	}
 
+	if r.diffs != nil {
+		result["diff"] = r.diffs
+	}
+
 	return result
 }
 
@@ -0,0 +1,173 @@
+/*
+ * Flow CLI
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package project
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/onflow/flow-cli/flow/lib"
+	"github.com/onflow/flow-cli/flow/services"
+	"github.com/onflow/flow-cli/pkg/flowkit/resolvers"
+)
+
+// ContractDiffStatus describes how a contract's local source compares against what,
+// if anything, is currently deployed to the target network.
+type ContractDiffStatus string
+
+const (
+	ContractDiffAdded     ContractDiffStatus = "added"
+	ContractDiffUnchanged ContractDiffStatus = "unchanged"
+	ContractDiffUpdated   ContractDiffStatus = "updated"
+	ContractDiffRemoved   ContractDiffStatus = "removed"
+)
+
+// ContractDiff reports the --dry-run result for a single contract: whether AddContract,
+// UpdateContract or nothing at all would happen, and a line diff (see unifiedDiff)
+// against whatever code is currently on chain.
+type ContractDiff struct {
+	Name      string             `json:"name"`
+	Target    string             `json:"target"`
+	Status    ContractDiffStatus `json:"status"`
+	Unified   string             `json:"diff,omitempty"`
+	ByteDelta int                `json:"byteDelta"`
+	LineDelta int                `json:"lineDelta"`
+}
+
+// diffDeployment fetches the on-chain code of every account targeted by the contracts
+// configured for network and compares it against the local source a real deploy would
+// send, returning one ContractDiff per contract in Deployment.Sort's dependency order
+// (each contract after the contracts it imports). The Deployment is built the same way
+// deployLayered builds it, so bundle-declared dependencies are expanded and each
+// contract's integrity digest, if any, is verified exactly as it would be on a real
+// deploy.
+func diffDeployment(project *lib.Project, svc *services.Services, network string) ([]*ContractDiff, error) {
+	projectContracts, err := project.ContractsByNetwork(network)
+	if err != nil {
+		return nil, err
+	}
+
+	loader := newProjectLoader()
+
+	deployment, err := resolvers.NewDeployment(projectContracts, loader)
+	if err != nil {
+		return nil, err
+	}
+
+	sorted, err := deployment.Sort()
+	if err != nil {
+		return nil, err
+	}
+
+	diffs := make([]*ContractDiff, 0, len(sorted))
+	for _, contract := range sorted {
+		localCode, err := loader.LoadWithIntegrity(contract.Location, contract.Integrity)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load contract %s: %w", contract.Name, err)
+		}
+
+		account, err := svc.Accounts.Get(contract.Target)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch account %s: %w", contract.Target, err)
+		}
+
+		diffs = append(diffs, newContractDiff(
+			contract.Name,
+			contract.Target.String(),
+			string(localCode),
+			string(account.Contracts[contract.Name]),
+		))
+	}
+
+	return diffs, nil
+}
+
+// newContractDiff compares the local contract source against the code currently
+// deployed on chain (an empty deployedCode means nothing is deployed yet).
+func newContractDiff(name string, target string, localCode string, deployedCode string) *ContractDiff {
+	diff := &ContractDiff{
+		Name:      name,
+		Target:    target,
+		ByteDelta: len(localCode) - len(deployedCode),
+		LineDelta: strings.Count(localCode, "\n") - strings.Count(deployedCode, "\n"),
+	}
+
+	switch {
+	case deployedCode == "" && localCode != "":
+		diff.Status = ContractDiffAdded
+	case deployedCode != "" && localCode == "":
+		diff.Status = ContractDiffRemoved
+	case deployedCode == localCode:
+		diff.Status = ContractDiffUnchanged
+	default:
+		diff.Status = ContractDiffUpdated
+	}
+
+	diff.Unified = unifiedDiff(target, deployedCode, localCode)
+
+	return diff
+}
+
+// unifiedDiff produces a minimal unified-style diff between two versions of a
+// contract's source: after the ---/+++ header it lists, with "-", every old line that
+// appears nowhere in new, then, with "+", every new line that appears nowhere in old.
+// Lines are compared as sets, so there are no @@ hunks or context lines, and a line
+// that only moved, or only changed how often it repeats, is not reported.
+func unifiedDiff(name string, old string, new string) string {
+	if old == new {
+		return ""
+	}
+
+	oldLines := splitLines(old)
+	newLines := splitLines(new)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- a/%s\n+++ b/%s\n", name, name)
+
+	for _, line := range oldLines {
+		if !contains(newLines, line) {
+			fmt.Fprintf(&b, "-%s\n", line)
+		}
+	}
+	for _, line := range newLines {
+		if !contains(oldLines, line) {
+			fmt.Fprintf(&b, "+%s\n", line)
+		}
+	}
+
+	return b.String()
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(s, "\n")
+}
+
+func contains(lines []string, line string) bool {
+	for _, l := range lines {
+		if l == line {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,238 @@
+/*
+ * Flow CLI
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package project
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/onflow/flow-cli/flow/lib"
+	"github.com/onflow/flow-cli/flow/lib/contracts"
+	"github.com/onflow/flow-cli/flow/services"
+	"github.com/onflow/flow-cli/pkg/flowkit"
+	"github.com/onflow/flow-cli/pkg/flowkit/resolvers"
+)
+
+// watchDebounce is how long to wait after the last file event in a burst before
+// reconciling, so a single save (which can fire several fsnotify events) only
+// triggers one redeploy cycle.
+const watchDebounce = 300 * time.Millisecond
+
+// watchMaxParallel bounds how many contracts a single reconcile redeploys at once -
+// the affected subgraph on a save is usually small, so this doesn't need to be
+// configurable the way deploy's --max-parallel is.
+const watchMaxParallel = 4
+
+// watchAndRedeploy watches every local contract location configured for network and,
+// on change, redeploys only the changed contract plus its transitive dependents.
+// Contracts resolved from a remote loader (github://, ipfs://, https://, bundle://)
+// have no local file to watch and are skipped.
+//
+// It runs until the watcher is closed or an unrecoverable error occurs. Cyclic-import
+// errors are reported and watching continues, so a developer can fix the cycle without
+// restarting the command.
+func watchAndRedeploy(
+	svc *services.Services,
+	project *lib.Project,
+	network string,
+	update bool,
+) error {
+	projectContracts, err := project.ContractsByNetwork(network)
+	if err != nil {
+		return err
+	}
+
+	deployment, err := resolvers.NewDeployment(projectContracts, newProjectLoader())
+	if err != nil {
+		return err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to start contract watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	for _, contract := range projectContracts {
+		if strings.Contains(contract.Location, "://") {
+			continue
+		}
+
+		if err := watcher.Add(contract.Location); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", contract.Location, err)
+		}
+	}
+
+	fmt.Println("Watching contracts for changes. Press Ctrl+C to stop.")
+
+	var mu sync.Mutex
+	var debounce *time.Timer
+	pending := make(map[string]bool)
+
+	// trigger hands a ready batch off to the single reconcile worker below. It's
+	// buffered by one and fed through a non-blocking send, so a debounce firing while a
+	// redeploy is still in flight just coalesces into the batch the worker picks up next,
+	// instead of starting a second reconcile concurrently against the same Deployment.
+	trigger := make(chan struct{}, 1)
+	defer close(trigger)
+
+	go func() {
+		for range trigger {
+			mu.Lock()
+			batch := pending
+			pending = make(map[string]bool)
+			mu.Unlock()
+
+			if len(batch) == 0 {
+				continue
+			}
+
+			// reparse every contract so edited imports, including a cycle introduced or
+			// removed since the previous reconcile, are seen by this one
+			rebuilt, err := resolvers.NewDeployment(projectContracts, newProjectLoader())
+			if err != nil {
+				fmt.Printf("redeploy failed: %s\n", err)
+				continue
+			}
+			deployment = rebuilt
+
+			reconcile(svc, deployment, update, batch)
+		}
+	}()
+
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+
+			if event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+				continue
+			}
+
+			mu.Lock()
+			pending[event.Name] = true
+			mu.Unlock()
+
+			if debounce != nil {
+				debounce.Stop()
+			}
+			debounce = time.AfterFunc(watchDebounce, func() {
+				select {
+				case trigger <- struct{}{}:
+				default:
+				}
+			})
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+
+			fmt.Printf("watch error: %s\n", err)
+		}
+	}
+}
+
+// reconcile redeploys the subgraph affected by the given set of changed locations -
+// each changed contract plus everything that transitively depends on it.
+func reconcile(svc *services.Services, deployment *resolvers.Deployment, update bool, changed map[string]bool) {
+	affected := make(map[string]*flowkit.Contract)
+
+	for location := range changed {
+		contract, err := deployment.ContractAt(location)
+		if err != nil {
+			fmt.Printf("redeploy failed: %s\n", err)
+			return
+		}
+		affected[contract.Location] = contract
+
+		dependents, err := deployment.Dependents(location)
+		if err != nil {
+			if _, ok := err.(*resolvers.CyclicImportError); ok {
+				fmt.Printf("cycle detected, still watching: %s\n", err)
+				return
+			}
+
+			fmt.Printf("redeploy failed: %s\n", err)
+			return
+		}
+
+		for _, dependent := range dependents {
+			affected[dependent.Location] = dependent
+		}
+	}
+
+	deployed, err := deployAffected(svc, deployment, affected, update)
+	if err != nil {
+		fmt.Printf("redeploy failed: %s\n", err)
+		return
+	}
+
+	names := make([]string, len(deployed))
+	for i, contract := range deployed {
+		names[i] = contract.Name()
+	}
+
+	fmt.Printf("redeployed %d contract(s): %v\n", len(deployed), names)
+}
+
+// deployAffected deploys exactly the contracts in affected, in dependency order: a
+// dependent must never be sent to chain concurrently with (or before) the contract it
+// imports, so this walks deployment's full Kahn layering and, layer by layer, deploys
+// only the affected contracts it finds there - contracts within a filtered layer still
+// have no dependency on one another and can be deployed concurrently.
+func deployAffected(
+	svc *services.Services,
+	deployment *resolvers.Deployment,
+	affected map[string]*flowkit.Contract,
+	update bool,
+) ([]*contracts.Contract, error) {
+	layers, err := deployment.Layers()
+	if err != nil {
+		return nil, err
+	}
+
+	deployed := make([]*contracts.Contract, 0, len(affected))
+
+	for _, layer := range layers {
+		subset := make([]*flowkit.Contract, 0, len(layer))
+		for _, contract := range layer {
+			if _, ok := affected[contract.Location]; ok {
+				subset = append(subset, contract)
+			}
+		}
+
+		if len(subset) == 0 {
+			continue
+		}
+
+		result, err := deployLayer(svc, subset, update, watchMaxParallel)
+		deployed = append(deployed, result...)
+		if err != nil {
+			return deployed, err
+		}
+	}
+
+	return deployed, nil
+}
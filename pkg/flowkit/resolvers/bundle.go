@@ -0,0 +1,444 @@
+/*
+ * Flow CLI
+ *
+ * Copyright 2019 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package resolvers
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/onflow/flow-cli/pkg/flowkit"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	bundleManifestFile  = "manifest.yaml"
+	bundleChecksumsFile = "checksums.sha256"
+	bundleScheme        = "bundle://"
+)
+
+// BundleManifest is the manifest.yaml of a contract bundle: its own name and version,
+// and the other bundles it depends on.
+type BundleManifest struct {
+	Name         string             `yaml:"name"`
+	Version      string             `yaml:"version"`
+	Dependencies []BundleDependency `yaml:"dependencies"`
+}
+
+// BundleDependency names a bundle and version that another bundle depends on.
+type BundleDependency struct {
+	Name    string `yaml:"name"`
+	Version string `yaml:"version"`
+}
+
+// Bundle is an unpacked .cbundle: its manifest, the declared sha256 of every file it
+// contains, and the file contents themselves keyed by path relative to the bundle root.
+type Bundle struct {
+	Manifest  BundleManifest
+	Checksums map[string]string
+	Files     map[string][]byte
+}
+
+// readManifestFile reads and parses sourceDir's manifest.yaml, returning both the
+// parsed manifest and the raw bytes - PackBundle needs the raw bytes to embed the file
+// unchanged in the .cbundle it writes.
+func readManifestFile(sourceDir string) ([]byte, BundleManifest, error) {
+	manifestPath := filepath.Join(sourceDir, bundleManifestFile)
+	manifestBytes, err := ioutil.ReadFile(manifestPath)
+	if err != nil {
+		return nil, BundleManifest{}, fmt.Errorf("failed to read %s: %w", bundleManifestFile, err)
+	}
+
+	var manifest BundleManifest
+	if err := yaml.Unmarshal(manifestBytes, &manifest); err != nil {
+		return nil, BundleManifest{}, fmt.Errorf("invalid %s: %w", bundleManifestFile, err)
+	}
+
+	return manifestBytes, manifest, nil
+}
+
+// ReadManifest reads and parses the manifest.yaml of the bundle source directory at
+// sourceDir, without packing anything - used to name the .cbundle before PackBundle
+// writes it.
+func ReadManifest(sourceDir string) (BundleManifest, error) {
+	_, manifest, err := readManifestFile(sourceDir)
+	return manifest, err
+}
+
+// PackBundle writes a gzipped tar .cbundle at outPath holding sourceDir's manifest.yaml
+// (which must already exist), every .cdc file under sourceDir, and a checksums.sha256
+// computed over those .cdc files. Other files in sourceDir are not included.
+func PackBundle(sourceDir string, outPath string) error {
+	manifestBytes, _, err := readManifestFile(sourceDir)
+	if err != nil {
+		return err
+	}
+
+	files := make(map[string][]byte)
+	err = filepath.Walk(sourceDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || filepath.Ext(path) != ".cdc" {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(sourceDir, path)
+		if err != nil {
+			return err
+		}
+
+		code, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		files[relPath] = code
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to collect contracts in %s: %w", sourceDir, err)
+	}
+
+	var checksums strings.Builder
+	for _, relPath := range sortedKeys(files) {
+		sum := sha256.Sum256(files[relPath])
+		fmt.Fprintf(&checksums, "%s  %s\n", hex.EncodeToString(sum[:]), relPath)
+	}
+
+	out, err := os.Create(outPath)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	gz := gzip.NewWriter(out)
+	defer gz.Close()
+
+	tw := tar.NewWriter(gz)
+	defer tw.Close()
+
+	if err := writeTarEntry(tw, bundleManifestFile, manifestBytes); err != nil {
+		return err
+	}
+	if err := writeTarEntry(tw, bundleChecksumsFile, []byte(checksums.String())); err != nil {
+		return err
+	}
+	for _, relPath := range sortedKeys(files) {
+		if err := writeTarEntry(tw, relPath, files[relPath]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeTarEntry(tw *tar.Writer, name string, content []byte) error {
+	err := tw.WriteHeader(&tar.Header{
+		Name: name,
+		Size: int64(len(content)),
+		Mode: 0644,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = tw.Write(content)
+	return err
+}
+
+func sortedKeys(files map[string][]byte) []string {
+	keys := make([]string, 0, len(files))
+	for k := range files {
+		keys = append(keys, k)
+	}
+
+	for i := 1; i < len(keys); i++ {
+		for j := i; j > 0 && keys[j-1] > keys[j]; j-- {
+			keys[j-1], keys[j] = keys[j], keys[j-1]
+		}
+	}
+
+	return keys
+}
+
+// UnpackBundle reads a .cbundle from disk and parses its manifest and checksums.
+func UnpackBundle(path string) (*Bundle, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bundle %s: %w", path, err)
+	}
+	defer gz.Close()
+
+	bundle := &Bundle{
+		Checksums: make(map[string]string),
+		Files:     make(map[string][]byte),
+	}
+
+	var manifestBytes []byte
+	var checksumsBytes []byte
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid bundle %s: %w", path, err)
+		}
+
+		content, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+
+		switch header.Name {
+		case bundleManifestFile:
+			manifestBytes = content
+		case bundleChecksumsFile:
+			checksumsBytes = content
+		default:
+			bundle.Files[header.Name] = content
+		}
+	}
+
+	if manifestBytes == nil {
+		return nil, fmt.Errorf("bundle %s is missing %s", path, bundleManifestFile)
+	}
+	if err := yaml.Unmarshal(manifestBytes, &bundle.Manifest); err != nil {
+		return nil, fmt.Errorf("invalid %s in bundle %s: %w", bundleManifestFile, path, err)
+	}
+
+	for _, line := range strings.Split(string(checksumsBytes), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		fields := strings.SplitN(line, "  ", 2)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid %s entry in bundle %s: %q", bundleChecksumsFile, path, line)
+		}
+
+		bundle.Checksums[fields[1]] = fields[0]
+	}
+
+	return bundle, nil
+}
+
+// FetchBundle downloads the raw .cbundle contents at url, for commands such as
+// "flow project bundle install" that need the bytes before they have a BundleRegistry
+// to resolve against.
+func FetchBundle(url string) ([]byte, error) {
+	return fetchHTTP(url)
+}
+
+// BundleRegistry resolves bundle name@version references against a local on-disk
+// cache, falling back to one or more remote HTTPS indexes that serve
+// <name>/<version>.cbundle files.
+type BundleRegistry struct {
+	cacheDir string
+	indexes  []string
+	bundles  map[string]*Bundle
+}
+
+// NewBundleRegistry creates a registry backed by cacheDir, consulting the given
+// remote HTTPS indexes (in order) for any bundle not already cached locally.
+func NewBundleRegistry(cacheDir string, indexes []string) *BundleRegistry {
+	return &BundleRegistry{
+		cacheDir: cacheDir,
+		indexes:  indexes,
+		bundles:  make(map[string]*Bundle),
+	}
+}
+
+// Resolve returns the Bundle for name@version, fetching and caching it if needed.
+func (r *BundleRegistry) Resolve(name string, version string) (*Bundle, error) {
+	key := name + "@" + version
+	if bundle, ok := r.bundles[key]; ok {
+		return bundle, nil
+	}
+
+	cachePath := filepath.Join(r.cacheDir, name, version+".cbundle")
+
+	if _, err := os.Stat(cachePath); err != nil {
+		if err := r.fetch(name, version, cachePath); err != nil {
+			return nil, err
+		}
+	}
+
+	bundle, err := UnpackBundle(cachePath)
+	if err != nil {
+		return nil, err
+	}
+
+	r.bundles[key] = bundle
+	return bundle, nil
+}
+
+func (r *BundleRegistry) fetch(name string, version string, cachePath string) error {
+	var lastErr error
+
+	for _, index := range r.indexes {
+		url := fmt.Sprintf("%s/%s/%s.cbundle", strings.TrimSuffix(index, "/"), name, version)
+
+		code, err := fetchHTTP(url)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(cachePath), 0755); err != nil {
+			return err
+		}
+
+		return ioutil.WriteFile(cachePath, code, 0644)
+	}
+
+	if lastErr != nil {
+		return fmt.Errorf("bundle %s@%s not found in any registry: %w", name, version, lastErr)
+	}
+
+	return fmt.Errorf("bundle %s@%s not found in any registry", name, version)
+}
+
+// BundleLoader resolves bundle://name@version/path/Contract.cdc locations against a
+// BundleRegistry, verifying each file's sha256 against the bundle's checksums.sha256
+// before returning it.
+type BundleLoader struct {
+	Registry *BundleRegistry
+}
+
+func (b *BundleLoader) Supports(location string) bool {
+	return strings.HasPrefix(location, bundleScheme)
+}
+
+func (b *BundleLoader) Load(location string) ([]byte, error) {
+	name, version, path, err := parseBundleLocation(location)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle, err := b.Registry.Resolve(name, version)
+	if err != nil {
+		return nil, err
+	}
+
+	return verifiedBundleFile(bundle, name, version, path)
+}
+
+// Normalize resolves a sibling import against the directory of fromLocation's path
+// within the same bundle, so a contract bundled alongside others can import them by
+// their own relative import string rather than the bundle:// location they were
+// registered under.
+func (b *BundleLoader) Normalize(fromLocation string, importPath string) string {
+	if strings.Contains(importPath, "://") {
+		return importPath
+	}
+
+	name, version, fromPath, err := parseBundleLocation(fromLocation)
+	if err != nil {
+		return importPath
+	}
+
+	resolved := path.Join(path.Dir(fromPath), importPath)
+
+	return fmt.Sprintf("%s%s@%s/%s", bundleScheme, name, version, resolved)
+}
+
+// ExpandDependencies resolves the bundles a bundle depends on and returns a
+// flowkit.Contract for every Cadence file they contain, so cross-bundle imports
+// participate in the normal dependency sort.
+func (b *BundleLoader) ExpandDependencies(location string) ([]*flowkit.Contract, error) {
+	name, version, _, err := parseBundleLocation(location)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle, err := b.Registry.Resolve(name, version)
+	if err != nil {
+		return nil, err
+	}
+
+	contracts := make([]*flowkit.Contract, 0, len(bundle.Manifest.Dependencies))
+	for _, dependency := range bundle.Manifest.Dependencies {
+		depBundle, err := b.Registry.Resolve(dependency.Name, dependency.Version)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, path := range sortedKeys(depBundle.Files) {
+			contracts = append(contracts, &flowkit.Contract{
+				Name:     strings.TrimSuffix(filepath.Base(path), ".cdc"),
+				Location: fmt.Sprintf("%s%s@%s/%s", bundleScheme, dependency.Name, dependency.Version, path),
+			})
+		}
+	}
+
+	return contracts, nil
+}
+
+func verifiedBundleFile(bundle *Bundle, name string, version string, path string) ([]byte, error) {
+	code, ok := bundle.Files[path]
+	if !ok {
+		return nil, fmt.Errorf("contract %s not found in bundle %s@%s", path, name, version)
+	}
+
+	want, ok := bundle.Checksums[path]
+	if !ok {
+		return nil, fmt.Errorf("no checksum declared for %s in bundle %s@%s", path, name, version)
+	}
+
+	if err := verifyDigest(code, want); err != nil {
+		return nil, fmt.Errorf("%s in bundle %s@%s: %w", path, name, version, err)
+	}
+
+	return code, nil
+}
+
+func parseBundleLocation(location string) (name string, version string, path string, err error) {
+	rest := strings.TrimPrefix(location, bundleScheme)
+
+	nameVersion, path, found := strings.Cut(rest, "/")
+	if !found || path == "" {
+		return "", "", "", fmt.Errorf("invalid bundle location, missing contract path: %s", location)
+	}
+
+	name, version, found = strings.Cut(nameVersion, "@")
+	if !found {
+		return "", "", "", fmt.Errorf("invalid bundle location, missing @version: %s", location)
+	}
+
+	return name, version, path, nil
+}
@@ -43,9 +43,10 @@ func newContract(contract *flowkit.Contract, index int64, code []byte) (*deployC
 	}
 
 	return &deployContract{
-		index:    index,
-		Contract: contract,
-		program:  program,
+		index:        index,
+		Contract:     contract,
+		program:      program,
+		dependencies: make(map[string]*deployContract),
 	}, nil
 }
 
@@ -85,6 +86,9 @@ type Deployment struct {
 }
 
 // NewDeployment from the flowkit Contracts and loaded from the contract location using a loader.
+//
+// The loader is typically a *LoaderRegistry, which dispatches each contract's Location to the
+// BundleLoader, GitHubLoader, IPFSLoader, HTTPSLoader or FileLoader registered for its scheme.
 func NewDeployment(contracts []*flowkit.Contract, loader Loader) (*Deployment, error) {
 	deployment := &Deployment{
 		loader:              loader,
@@ -102,10 +106,13 @@ func NewDeployment(contracts []*flowkit.Contract, loader Loader) (*Deployment, e
 }
 
 func (d *Deployment) add(contract *flowkit.Contract) error {
-	// TODO implement group of loaders detecting the location format and choosing the one supporting that format to load the contract - this will be relevant for multiple locations like flow, ifps, github etc
-	code, err := d.loader.Load(contract.Location)
+	if _, exists := d.contractsByLocation[contract.Location]; exists {
+		return nil
+	}
+
+	code, err := d.loadContract(contract)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to resolve import for contract %s: %w", contract.Name, err)
 	}
 
 	c, err := newContract(contract, int64(len(d.contracts)), code)
@@ -116,9 +123,36 @@ func (d *Deployment) add(contract *flowkit.Contract) error {
 	d.contracts = append(d.contracts, c)
 	d.contractsByLocation[c.Location] = c
 
+	if expander, ok := d.loader.(BundleDependencyExpander); ok {
+		dependencies, err := expander.ExpandDependencies(contract.Location)
+		if err != nil {
+			return fmt.Errorf("failed to expand bundle dependencies for %s: %w", contract.Name, err)
+		}
+
+		for _, dependency := range dependencies {
+			if err := d.add(dependency); err != nil {
+				return err
+			}
+		}
+	}
+
 	return nil
 }
 
+// loadContract fetches a contract's source, verifying it against contract.Integrity
+// when the contract declares one and the configured loader supports integrity
+// verification (currently only *LoaderRegistry).
+func (d *Deployment) loadContract(contract *flowkit.Contract) ([]byte, error) {
+	verifier, ok := d.loader.(interface {
+		LoadWithIntegrity(location string, sha256Digest string) ([]byte, error)
+	})
+	if !ok {
+		return d.loader.Load(contract.Location)
+	}
+
+	return verifier.LoadWithIntegrity(contract.Location, contract.Integrity)
+}
+
 // Sort contracts by deployment order.
 //
 // Order of sorting is dependent on the possible imports contract contains, since
@@ -143,11 +177,128 @@ func (d *Deployment) Sort() ([]*flowkit.Contract, error) {
 	return contracts, nil
 }
 
+// ContractAt returns the contract registered at location, so a caller that already has
+// Dependents can also recover the changed contract itself - the two together are the
+// full subgraph that must be redeployed.
+func (d *Deployment) ContractAt(location string) (*flowkit.Contract, error) {
+	contract, ok := d.contractsByLocation[location]
+	if !ok {
+		return nil, fmt.Errorf("no contract found for location: %s", location)
+	}
+
+	return contract.Contract, nil
+}
+
+// Dependents returns every contract, transitively, that imports the contract at the
+// given location - the changed contract plus this set is exactly the subgraph that
+// must be redeployed when that location changes.
+func (d *Deployment) Dependents(location string) ([]*flowkit.Contract, error) {
+	err := d.buildDependencies()
+	if err != nil {
+		return nil, err
+	}
+
+	changed, ok := d.contractsByLocation[location]
+	if !ok {
+		return nil, fmt.Errorf("no contract found for location: %s", location)
+	}
+
+	dependents := make(map[int64]*deployContract)
+	queue := []*deployContract{changed}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, contract := range d.contracts {
+			if _, found := contract.dependencies[current.Location]; !found {
+				continue
+			}
+
+			if _, seen := dependents[contract.index]; seen {
+				continue
+			}
+
+			dependents[contract.index] = contract
+			queue = append(queue, contract)
+		}
+	}
+
+	result := make([]*flowkit.Contract, 0, len(dependents))
+	for _, c := range d.contracts {
+		if dep, ok := dependents[c.index]; ok {
+			result = append(result, dep.Contract)
+		}
+	}
+
+	return result, nil
+}
+
+// Layers groups contracts into Kahn-style deployment levels.
+//
+// Level 0 contains every contract with no dependencies. Each subsequent level contains
+// the contracts whose dependencies are all satisfied by earlier levels, so contracts
+// within a level have no dependency relationship between them and can be deployed
+// concurrently. Cyclic imports are detected before any layer is computed, the same way
+// Sort detects them, so callers get a CyclicImportError rather than an incomplete result.
+func (d *Deployment) Layers() ([][]*flowkit.Contract, error) {
+	err := d.buildDependencies()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := sortByDeploymentOrder(d.contracts); err != nil {
+		return nil, err
+	}
+
+	remaining := make(map[int64]struct{}, len(d.contracts))
+	dependents := make(map[int64][]*deployContract)
+	inDegree := make(map[int64]int, len(d.contracts))
+
+	for _, c := range d.contracts {
+		remaining[c.index] = struct{}{}
+		inDegree[c.index] = len(c.dependencies)
+
+		for _, dep := range c.dependencies {
+			dependents[dep.index] = append(dependents[dep.index], c)
+		}
+	}
+
+	var layers [][]*flowkit.Contract
+
+	for len(remaining) > 0 {
+		var level []*deployContract
+
+		// iterate in original insertion order so levels are deterministic,
+		// matching the stable-sort guarantees of Sort.
+		for _, c := range d.contracts {
+			if _, ok := remaining[c.index]; ok && inDegree[c.index] == 0 {
+				level = append(level, c)
+			}
+		}
+
+		contracts := make([]*flowkit.Contract, len(level))
+		for i, c := range level {
+			contracts[i] = c.Contract
+			delete(remaining, c.index)
+
+			for _, dependent := range dependents[c.index] {
+				inDegree[dependent.index]--
+			}
+		}
+
+		layers = append(layers, contracts)
+	}
+
+	return layers, nil
+}
+
 // buildDependencies iterates over all contracts and checks the imports which are added as its dependencies.
 func (d *Deployment) buildDependencies() error {
 	for _, contract := range d.contracts {
 		for _, location := range contract.imports() {
-			importPath := location // TODO: i.loader.Normalize(program.source, source)
+			importPath := d.loader.Normalize(contract.Location, location)
+
 			importContract, isContract := d.contractsByLocation[importPath]
 			// todo is it we removed aliases here?
 			if !isContract {
@@ -159,7 +310,7 @@ func (d *Deployment) buildDependencies() error {
 
 			}
 
-			contract.addDependency(location, importContract)
+			contract.addDependency(importPath, importContract)
 		}
 	}
 
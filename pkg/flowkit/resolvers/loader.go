@@ -0,0 +1,409 @@
+/*
+ * Flow CLI
+ *
+ * Copyright 2019 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package resolvers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/onflow/flow-cli/pkg/flowkit"
+)
+
+// Loader loads the source of a contract from a location.
+//
+// A location is whatever string is configured on a contract in flow.json - it can be
+// a path relative to the current working directory, or a URI using one of the schemes
+// supported by a LoaderRegistry (bundle://, github://, ipfs://, https://).
+type Loader interface {
+	// Supports reports whether this loader knows how to resolve the given location.
+	Supports(location string) bool
+	// Load fetches the contract source for the given location.
+	Load(location string) ([]byte, error)
+	// Normalize resolves a Cadence import string found in the contract at fromLocation
+	// into the absolute location it actually refers to. Cadence source imports siblings
+	// by their own repo-relative path (e.g. "./Token.cdc"), not by the scheme-qualified
+	// location a dependency was declared under in flow.json, so buildDependencies needs
+	// this to match an import against the registered contract it points to. An
+	// importPath that is already absolute (contains "://") is returned unchanged.
+	Normalize(fromLocation string, importPath string) string
+}
+
+// LoaderRegistry dispatches a location to the first registered Loader that supports it.
+//
+// Loaders are tried in registration order, so more specific schemes should be registered
+// before general fallbacks such as FileLoader.
+type LoaderRegistry struct {
+	loaders []Loader
+	cache   *loaderCache
+}
+
+// NewLoaderRegistry creates a registry with the default set of loaders: bundle,
+// GitHub, IPFS, HTTPS and finally a FileLoader as the fallback for plain filesystem
+// paths.
+func NewLoaderRegistry(bundles *BundleRegistry) *LoaderRegistry {
+	registry := &LoaderRegistry{
+		cache: newLoaderCache(),
+	}
+
+	registry.Register(&BundleLoader{Registry: bundles})
+	registry.Register(&GitHubLoader{Token: os.Getenv("GITHUB_TOKEN")})
+	registry.Register(&IPFSLoader{Gateway: defaultIPFSGateway})
+	registry.Register(&HTTPSLoader{})
+	registry.Register(&FileLoader{})
+
+	return registry
+}
+
+// Register adds a loader to the registry. Loaders registered earlier take precedence.
+func (r *LoaderRegistry) Register(loader Loader) {
+	r.loaders = append(r.loaders, loader)
+}
+
+// Load resolves the location against the registered loaders and returns its contents.
+//
+// Results are cached by resolved location. Load checks no integrity digest;
+// LoadWithIntegrity does, so a flow.json can pin a remote contract to a known hash.
+func (r *LoaderRegistry) Load(location string) ([]byte, error) {
+	return r.LoadWithIntegrity(location, "")
+}
+
+// LoadWithIntegrity behaves like Load but verifies the fetched bytes against an optional
+// sha256 digest declared for the contract. An empty digest skips verification.
+func (r *LoaderRegistry) LoadWithIntegrity(location string, sha256Digest string) ([]byte, error) {
+	if cached, ok := r.cache.get(location); ok {
+		if sha256Digest != "" {
+			if err := verifyDigest(cached, sha256Digest); err != nil {
+				return nil, fmt.Errorf("failed to load contract from %s: %w", location, err)
+			}
+		}
+
+		return cached, nil
+	}
+
+	loader := r.loaderFor(location)
+	if loader == nil {
+		return nil, fmt.Errorf("no loader registered for location: %s", location)
+	}
+
+	code, err := loader.Load(location)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load contract from %s: %w", location, err)
+	}
+
+	if sha256Digest != "" {
+		if err := verifyDigest(code, sha256Digest); err != nil {
+			return nil, fmt.Errorf("failed to load contract from %s: %w", location, err)
+		}
+	}
+
+	r.cache.set(location, code)
+
+	return code, nil
+}
+
+// BundleDependencyExpander is implemented by loaders that can declare additional
+// contracts a location depends on - currently only BundleLoader, whose manifest.yaml
+// can name dependencies on other bundles. Deployment uses this to pull those
+// dependencies into the graph before buildDependencies runs.
+type BundleDependencyExpander interface {
+	ExpandDependencies(location string) ([]*flowkit.Contract, error)
+}
+
+// ExpandDependencies delegates to whichever registered loader supports the location,
+// if that loader is a BundleDependencyExpander. Locations resolved by loaders that
+// don't declare bundle dependencies (FileLoader, GitHubLoader, ...) return nil.
+func (r *LoaderRegistry) ExpandDependencies(location string) ([]*flowkit.Contract, error) {
+	expander, ok := r.loaderFor(location).(BundleDependencyExpander)
+	if !ok {
+		return nil, nil
+	}
+
+	return expander.ExpandDependencies(location)
+}
+
+func (r *LoaderRegistry) loaderFor(location string) Loader {
+	for _, loader := range r.loaders {
+		if loader.Supports(location) {
+			return loader
+		}
+	}
+
+	return nil
+}
+
+// Supports reports whether any registered loader can resolve location, so a
+// *LoaderRegistry can itself be passed anywhere a Loader is expected.
+func (r *LoaderRegistry) Supports(location string) bool {
+	return r.loaderFor(location) != nil
+}
+
+// Normalize dispatches to whichever registered loader resolves fromLocation. If no
+// loader matches, importPath is returned unchanged.
+func (r *LoaderRegistry) Normalize(fromLocation string, importPath string) string {
+	loader := r.loaderFor(fromLocation)
+	if loader == nil {
+		return importPath
+	}
+
+	return loader.Normalize(fromLocation, importPath)
+}
+
+func verifyDigest(code []byte, want string) error {
+	sum := sha256.Sum256(code)
+	got := hex.EncodeToString(sum[:])
+
+	if !strings.EqualFold(got, want) {
+		return fmt.Errorf("integrity check failed: expected sha256 %s, got %s", want, got)
+	}
+
+	return nil
+}
+
+// loaderCache memoizes loaded contract sources by their resolved location so a deploy
+// that references the same remote contract multiple times only fetches it once.
+type loaderCache struct {
+	entries map[string][]byte
+}
+
+func newLoaderCache() *loaderCache {
+	return &loaderCache{entries: make(map[string][]byte)}
+}
+
+func (c *loaderCache) get(location string) ([]byte, bool) {
+	code, ok := c.entries[location]
+	return code, ok
+}
+
+func (c *loaderCache) set(location string, code []byte) {
+	c.entries[location] = code
+}
+
+// FileLoader loads contracts from the local filesystem. This is the default loader
+// used when a contract location is a plain path, and preserves the previous behavior
+// of Deployment before the LoaderRegistry was introduced.
+type FileLoader struct{}
+
+func (f *FileLoader) Supports(location string) bool {
+	return !strings.Contains(location, "://")
+}
+
+func (f *FileLoader) Load(location string) ([]byte, error) {
+	return ioutil.ReadFile(location)
+}
+
+// Normalize is a no-op for FileLoader: local imports are already matched against the
+// exact path configured in flow.json.
+func (f *FileLoader) Normalize(fromLocation string, importPath string) string {
+	return importPath
+}
+
+// GitHubLoader loads contracts from a GitHub repository using a
+// github://owner/repo@ref/path/to/Contract.cdc location via the GitHub REST API.
+type GitHubLoader struct {
+	// APIBase allows overriding the GitHub API host (default https://api.github.com),
+	// primarily for testing.
+	APIBase string
+	// Token, if set, is sent as a bearer token on every request. This both raises the
+	// unauthenticated rate limit and allows resolving private repositories; it defaults
+	// to the GITHUB_TOKEN environment variable when the loader is constructed by
+	// NewLoaderRegistry.
+	Token string
+}
+
+const githubScheme = "github://"
+
+func (g *GitHubLoader) Supports(location string) bool {
+	return strings.HasPrefix(location, githubScheme)
+}
+
+func (g *GitHubLoader) Load(location string) ([]byte, error) {
+	owner, repo, ref, path, err := parseGitHubLocation(location)
+	if err != nil {
+		return nil, err
+	}
+
+	base := g.APIBase
+	if base == "" {
+		base = "https://api.github.com"
+	}
+
+	url := fmt.Sprintf("%s/repos/%s/%s/contents/%s?ref=%s", base, owner, repo, path, ref)
+
+	return fetchGitHubContent(url, g.Token)
+}
+
+// fetchGitHubContent calls the GitHub REST "contents" endpoint asking for the raw
+// media type, so the response body is the file's bytes directly rather than the
+// default JSON envelope with a base64-encoded content field. A non-empty token is sent
+// as a bearer credential.
+func fetchGitHubContent(url string, token string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github.raw+json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d fetching %s", resp.StatusCode, url)
+	}
+
+	return ioutil.ReadAll(resp.Body)
+}
+
+// Normalize resolves a sibling import against the directory of fromLocation within the
+// same repo@ref, so "import Token from \"./Token.cdc\"" in a contract loaded from
+// github://owner/repo@ref/a/Contract.cdc matches the github://owner/repo@ref/a/Token.cdc
+// location that dependency was declared under.
+func (g *GitHubLoader) Normalize(fromLocation string, importPath string) string {
+	if strings.Contains(importPath, "://") {
+		return importPath
+	}
+
+	owner, repo, ref, fromPath, err := parseGitHubLocation(fromLocation)
+	if err != nil {
+		return importPath
+	}
+
+	resolved := path.Join(path.Dir(fromPath), importPath)
+
+	return fmt.Sprintf("%s%s/%s@%s/%s", githubScheme, owner, repo, ref, resolved)
+}
+
+func parseGitHubLocation(location string) (owner string, repo string, ref string, path string, err error) {
+	rest := strings.TrimPrefix(location, githubScheme)
+
+	ownerRepo, path, found := strings.Cut(rest, "/")
+	if !found {
+		return "", "", "", "", fmt.Errorf("invalid github location: %s", location)
+	}
+
+	owner, repoRef, found := strings.Cut(ownerRepo, "/")
+	if !found {
+		return "", "", "", "", fmt.Errorf("invalid github location: %s", location)
+	}
+
+	repo, ref, found = strings.Cut(repoRef, "@")
+	if !found {
+		return "", "", "", "", fmt.Errorf("invalid github location, missing @ref: %s", location)
+	}
+
+	if path == "" {
+		return "", "", "", "", fmt.Errorf("invalid github location, missing contract path: %s", location)
+	}
+
+	return owner, repo, ref, path, nil
+}
+
+// IPFSLoader loads contracts from IPFS via a configurable HTTP gateway, given a
+// location of the form ipfs://<cid>.
+type IPFSLoader struct {
+	Gateway string
+}
+
+const ipfsScheme = "ipfs://"
+const defaultIPFSGateway = "https://ipfs.io/ipfs"
+
+func (i *IPFSLoader) Supports(location string) bool {
+	return strings.HasPrefix(location, ipfsScheme)
+}
+
+func (i *IPFSLoader) Load(location string) ([]byte, error) {
+	cid := strings.TrimPrefix(location, ipfsScheme)
+	if cid == "" {
+		return nil, fmt.Errorf("invalid ipfs location, missing cid: %s", location)
+	}
+
+	gateway := i.Gateway
+	if gateway == "" {
+		gateway = defaultIPFSGateway
+	}
+
+	return fetchHTTP(fmt.Sprintf("%s/%s", strings.TrimSuffix(gateway, "/"), cid))
+}
+
+// Normalize resolves a sibling import against the directory part of fromLocation's CID
+// path, so a directory CID (ipfs://<cid>/a/Contract.cdc) can import a sibling file
+// (ipfs://<cid>/a/Token.cdc) by its own relative import string.
+func (i *IPFSLoader) Normalize(fromLocation string, importPath string) string {
+	if strings.Contains(importPath, "://") {
+		return importPath
+	}
+
+	fromPath := strings.TrimPrefix(fromLocation, ipfsScheme)
+	resolved := path.Join(path.Dir(fromPath), importPath)
+
+	return ipfsScheme + resolved
+}
+
+// HTTPSLoader loads contracts from a raw https:// URL.
+type HTTPSLoader struct{}
+
+func (h *HTTPSLoader) Supports(location string) bool {
+	return strings.HasPrefix(location, "https://")
+}
+
+func (h *HTTPSLoader) Load(location string) ([]byte, error) {
+	return fetchHTTP(location)
+}
+
+// Normalize resolves a sibling import against the directory of fromLocation's URL path.
+func (h *HTTPSLoader) Normalize(fromLocation string, importPath string) string {
+	if strings.Contains(importPath, "://") {
+		return importPath
+	}
+
+	u, err := url.Parse(fromLocation)
+	if err != nil {
+		return importPath
+	}
+
+	u.Path = path.Join(path.Dir(u.Path), importPath)
+
+	return u.String()
+}
+
+func fetchHTTP(url string) ([]byte, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d fetching %s", resp.StatusCode, url)
+	}
+
+	return ioutil.ReadAll(resp.Body)
+}